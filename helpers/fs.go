@@ -0,0 +1,270 @@
+// Copyright © 2014 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fs is the filesystem abstraction used by every path/file helper in this
+// package. It covers just enough of the standard library's os package to
+// support Hugo's needs, so it can be backed by the real disk, an in-memory
+// tree for tests, or a read-only overlay of several trees.
+type Fs interface {
+	Stat(name string) (os.FileInfo, error)
+	Open(name string) (io.ReadCloser, error)
+	Create(name string) (io.WriteCloser, error)
+	MkdirAll(path string, perm os.FileMode) error
+	ReadDir(dirname string) ([]os.FileInfo, error)
+}
+
+// OsFs is the default Fs, backed directly by the local disk.
+type OsFs struct{}
+
+// Stat implements Fs.
+func (OsFs) Stat(name string) (os.FileInfo, error) { return os.Stat(name) }
+
+// Open implements Fs.
+func (OsFs) Open(name string) (io.ReadCloser, error) { return os.Open(name) }
+
+// Create implements Fs.
+func (OsFs) Create(name string) (io.WriteCloser, error) { return os.Create(name) }
+
+// MkdirAll implements Fs.
+func (OsFs) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+
+// ReadDir implements Fs.
+func (OsFs) ReadDir(dirname string) ([]os.FileInfo, error) {
+	f, err := os.Open(dirname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Readdir(-1)
+}
+
+// memFileInfo is the os.FileInfo backing a MemFs entry.
+type memFileInfo struct {
+	name  string
+	size  int64
+	isDir bool
+}
+
+func (fi *memFileInfo) Name() string       { return fi.name }
+func (fi *memFileInfo) Size() int64        { return fi.size }
+func (fi *memFileInfo) Mode() os.FileMode  { return 0644 }
+func (fi *memFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi *memFileInfo) IsDir() bool        { return fi.isDir }
+func (fi *memFileInfo) Sys() interface{}   { return nil }
+
+// MemFs is an in-memory Fs, primarily intended for tests that shouldn't
+// have to touch the real disk.
+type MemFs struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+	dirs  map[string]bool
+}
+
+// NewMemFs returns an empty, ready to use MemFs.
+func NewMemFs() *MemFs {
+	return &MemFs{
+		files: make(map[string][]byte),
+		dirs:  map[string]bool{".": true},
+	}
+}
+
+func memClean(name string) string {
+	return filepath.Clean(filepath.ToSlash(name))
+}
+
+// Stat implements Fs.
+func (m *MemFs) Stat(name string) (os.FileInfo, error) {
+	name = memClean(name)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if data, ok := m.files[name]; ok {
+		return &memFileInfo{name: filepath.Base(name), size: int64(len(data))}, nil
+	}
+	if m.dirs[name] {
+		return &memFileInfo{name: filepath.Base(name), isDir: true}, nil
+	}
+	return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+}
+
+// Open implements Fs.
+func (m *MemFs) Open(name string) (io.ReadCloser, error) {
+	name = memClean(name)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, ok := m.files[name]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Create implements Fs.
+func (m *MemFs) Create(name string) (io.WriteCloser, error) {
+	name = memClean(name)
+	if err := m.MkdirAll(filepath.Dir(name), 0777); err != nil {
+		return nil, err
+	}
+	return &memFile{fs: m, name: name}, nil
+}
+
+// MkdirAll implements Fs.
+func (m *MemFs) MkdirAll(path string, perm os.FileMode) error {
+	path = memClean(path)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for dir := path; dir != "." && dir != "/"; dir = filepath.Dir(dir) {
+		m.dirs[dir] = true
+	}
+	m.dirs["."] = true
+	return nil
+}
+
+// ReadDir implements Fs. Entries are classified by what they actually are
+// in the tree (file vs. directory), not by which map happened to be
+// iterated first, and a nonexistent dirname reports os.ErrNotExist like
+// OsFs.ReadDir and MemFs.Stat do.
+func (m *MemFs) ReadDir(dirname string) ([]os.FileInfo, error) {
+	dirname = memClean(dirname)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.dirs[dirname] {
+		return nil, &os.PathError{Op: "open", Path: dirname, Err: os.ErrNotExist}
+	}
+
+	entries := make(map[string]*memFileInfo)
+
+	for d := range m.dirs {
+		if d != dirname && filepath.Dir(d) == dirname {
+			name := filepath.Base(d)
+			entries[name] = &memFileInfo{name: name, isDir: true}
+		}
+	}
+	for f, data := range m.files {
+		if filepath.Dir(f) == dirname {
+			name := filepath.Base(f)
+			entries[name] = &memFileInfo{name: name, size: int64(len(data))}
+		}
+	}
+
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, fi := range entries {
+		infos = append(infos, fi)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	return infos, nil
+}
+
+func (m *MemFs) write(name string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[name] = data
+}
+
+// memFile buffers writes until Close, then commits them to the MemFs.
+type memFile struct {
+	fs   *MemFs
+	name string
+	buf  bytes.Buffer
+}
+
+func (f *memFile) Write(p []byte) (int, error) { return f.buf.Write(p) }
+
+func (f *memFile) Close() error {
+	f.fs.write(f.name, f.buf.Bytes())
+	return nil
+}
+
+// OverlayFs is a read-only Fs that layers an overlay tree on top of a base
+// tree without copying anything: lookups check the overlay first and fall
+// through to the base on a miss. This lets users mount a theme's static and
+// content directories on top of a site directory.
+type OverlayFs struct {
+	Base    Fs
+	Overlay Fs
+}
+
+// NewOverlayFs returns an OverlayFs that prefers overlay over base.
+func NewOverlayFs(base, overlay Fs) *OverlayFs {
+	return &OverlayFs{Base: base, Overlay: overlay}
+}
+
+// Stat implements Fs.
+func (o *OverlayFs) Stat(name string) (os.FileInfo, error) {
+	if fi, err := o.Overlay.Stat(name); err == nil {
+		return fi, nil
+	}
+	return o.Base.Stat(name)
+}
+
+// Open implements Fs.
+func (o *OverlayFs) Open(name string) (io.ReadCloser, error) {
+	if r, err := o.Overlay.Open(name); err == nil {
+		return r, nil
+	}
+	return o.Base.Open(name)
+}
+
+// Create implements Fs. OverlayFs is read-only; writes always fail.
+func (o *OverlayFs) Create(name string) (io.WriteCloser, error) {
+	return nil, fmt.Errorf("overlay filesystem is read-only, cannot create %q", name)
+}
+
+// MkdirAll implements Fs. OverlayFs is read-only; writes always fail.
+func (o *OverlayFs) MkdirAll(path string, perm os.FileMode) error {
+	return fmt.Errorf("overlay filesystem is read-only, cannot create directory %q", path)
+}
+
+// ReadDir implements Fs, merging entries from both trees with the overlay
+// taking precedence when a name exists in both.
+func (o *OverlayFs) ReadDir(dirname string) ([]os.FileInfo, error) {
+	seen := make(map[string]os.FileInfo)
+
+	base, baseErr := o.Base.ReadDir(dirname)
+	for _, fi := range base {
+		seen[fi.Name()] = fi
+	}
+
+	overlay, overlayErr := o.Overlay.ReadDir(dirname)
+	for _, fi := range overlay {
+		seen[fi.Name()] = fi
+	}
+
+	if baseErr != nil && overlayErr != nil {
+		return nil, baseErr
+	}
+
+	infos := make([]os.FileInfo, 0, len(seen))
+	for _, fi := range seen {
+		infos = append(infos, fi)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	return infos, nil
+}
@@ -0,0 +1,218 @@
+// Copyright © 2014 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestMemFsRoundTrip(t *testing.T) {
+	fs := NewMemFs()
+
+	w, err := fs.Create("sub/hello.txt")
+	if err != nil {
+		t.Fatalf("Create failed: %s", err)
+	}
+	if _, err := w.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %s", err)
+	}
+
+	fi, err := fs.Stat("sub/hello.txt")
+	if err != nil {
+		t.Fatalf("Stat failed: %s", err)
+	}
+	if fi.IsDir() {
+		t.Error("expected a file, got a directory")
+	}
+	if fi.Size() != 5 {
+		t.Errorf("expected size 5, got %d", fi.Size())
+	}
+
+	r, err := fs.Open("sub/hello.txt")
+	if err != nil {
+		t.Fatalf("Open failed: %s", err)
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %s", err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("expected %q, got %q", "hello", string(data))
+	}
+
+	if _, err := fs.Open("sub/missing.txt"); err == nil {
+		t.Error("expected an error opening a nonexistent file")
+	}
+}
+
+func TestMemFsReadDir(t *testing.T) {
+	fs := NewMemFs()
+
+	for _, name := range []string{"sub/a.txt", "sub/b.txt", "top.txt"} {
+		w, err := fs.Create(name)
+		if err != nil {
+			t.Fatalf("Create(%q) failed: %s", name, err)
+		}
+		w.Close()
+	}
+
+	root, err := fs.ReadDir(".")
+	if err != nil {
+		t.Fatalf("ReadDir(.) failed: %s", err)
+	}
+	rootNames := fileInfoNames(root)
+	if !containsAll(rootNames, "top.txt", "sub") {
+		t.Errorf("expected root listing to contain top.txt and sub, got %v", rootNames)
+	}
+
+	sub, err := fs.ReadDir("sub")
+	if err != nil {
+		t.Fatalf("ReadDir(sub) failed: %s", err)
+	}
+	subNames := fileInfoNames(sub)
+	if !containsAll(subNames, "a.txt", "b.txt") {
+		t.Errorf("expected sub listing to contain a.txt and b.txt, got %v", subNames)
+	}
+
+	for _, fi := range root {
+		if fi.Name() == "sub" && !fi.IsDir() {
+			t.Error("expected \"sub\" to be reported as a directory, IsDir() was false")
+		}
+	}
+}
+
+func TestMemFsReadDirNonExistent(t *testing.T) {
+	fs := NewMemFs()
+
+	if _, err := fs.ReadDir("nope"); !os.IsNotExist(err) {
+		t.Errorf("ReadDir(nope) error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestOverlayFsPrefersOverlay(t *testing.T) {
+	base := NewMemFs()
+	overlay := NewMemFs()
+
+	writeMemFile(t, base, "same.txt", "base")
+	writeMemFile(t, base, "base-only.txt", "base-only")
+	writeMemFile(t, overlay, "same.txt", "overlay")
+	writeMemFile(t, overlay, "overlay-only.txt", "overlay-only")
+
+	ofs := NewOverlayFs(base, overlay)
+
+	assertFileContents(t, ofs, "same.txt", "overlay")
+	assertFileContents(t, ofs, "base-only.txt", "base-only")
+	assertFileContents(t, ofs, "overlay-only.txt", "overlay-only")
+
+	if _, err := ofs.Create("nope.txt"); err == nil {
+		t.Error("expected OverlayFs.Create to fail, it is read-only")
+	}
+	if err := ofs.MkdirAll("nope", 0777); err == nil {
+		t.Error("expected OverlayFs.MkdirAll to fail, it is read-only")
+	}
+
+	entries, err := ofs.ReadDir(".")
+	if err != nil {
+		t.Fatalf("ReadDir(.) failed: %s", err)
+	}
+	names := fileInfoNames(entries)
+	if !containsAll(names, "same.txt", "base-only.txt", "overlay-only.txt") {
+		t.Errorf("expected merged listing, got %v", names)
+	}
+}
+
+func TestPathHelpersWithMemFs(t *testing.T) {
+	fs := NewMemFs()
+	writeMemFile(t, fs, "dir/file.txt", "content")
+
+	if exists, err := Exists(fs, "dir/file.txt"); err != nil || !exists {
+		t.Errorf("Exists(dir/file.txt) = %v, %v; want true, nil", exists, err)
+	}
+	if exists, err := Exists(fs, "dir/missing.txt"); err != nil || exists {
+		t.Errorf("Exists(dir/missing.txt) = %v, %v; want false, nil", exists, err)
+	}
+	if isDir, err := DirExists(fs, "dir"); err != nil || !isDir {
+		t.Errorf("DirExists(dir) = %v, %v; want true, nil", isDir, err)
+	}
+
+	if err := SafeWriteToDisk(fs, "dir/new.txt", bytes.NewReader([]byte("new content"))); err != nil {
+		t.Fatalf("SafeWriteToDisk failed: %s", err)
+	}
+	assertFileContents(t, fs, "dir/new.txt", "new content")
+
+	if err := SafeWriteToDisk(fs, "dir/new.txt", bytes.NewReader([]byte("new content"))); err == nil {
+		t.Error("expected SafeWriteToDisk to fail when the file already exists")
+	}
+}
+
+func writeMemFile(t *testing.T, fs *MemFs, name, contents string) {
+	t.Helper()
+	w, err := fs.Create(name)
+	if err != nil {
+		t.Fatalf("Create(%q) failed: %s", name, err)
+	}
+	if _, err := w.Write([]byte(contents)); err != nil {
+		t.Fatalf("Write(%q) failed: %s", name, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close(%q) failed: %s", name, err)
+	}
+}
+
+func assertFileContents(t *testing.T, fs Fs, name, want string) {
+	t.Helper()
+	r, err := fs.Open(name)
+	if err != nil {
+		t.Fatalf("Open(%q) failed: %s", name, err)
+	}
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll(%q) failed: %s", name, err)
+	}
+	if string(data) != want {
+		t.Errorf("Open(%q): expected %q, got %q", name, want, string(data))
+	}
+}
+
+func fileInfoNames(infos []os.FileInfo) []string {
+	names := make([]string, len(infos))
+	for i, fi := range infos {
+		names[i] = fi.Name()
+	}
+	return names
+}
+
+func containsAll(haystack []string, wanted ...string) bool {
+	for _, w := range wanted {
+		found := false
+		for _, h := range haystack {
+			if h == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
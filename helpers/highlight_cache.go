@@ -0,0 +1,110 @@
+// Copyright © 2013-14 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+// highlightCacheDir returns the directory under which highlighted code
+// fragments are cached, e.g. "<CacheDir>/highlight".
+func highlightCacheDir() string {
+	base := viper.GetString("CacheDir")
+	if base == "" {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "highlight")
+}
+
+// highlightCacheKey fingerprints everything that can change the rendered
+// output, so a cache hit is only ever served for identical input.
+func highlightCacheKey(backend, lexer, style, lineNumbers, code string) string {
+	// PygmentsUseClasses changes PygmentsHighlighter's output (inline styles
+	// vs CSS classes) without touching any of the other parameters, so it
+	// has to be folded in here too even though only that backend reads it.
+	pygmentsUseClasses := "0"
+	if viper.GetBool("PygmentsUseClasses") {
+		pygmentsUseClasses = "1"
+	}
+
+	h := sha256.New()
+	for _, part := range []string{backend, lexer, style, lineNumbers, pygmentsUseClasses, code} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// highlightCachePath turns a cache key into "<dir>/<first2>/<rest>.html",
+// spreading files across subdirectories to avoid huge flat directories.
+func highlightCachePath(key string) string {
+	return filepath.Join(highlightCacheDir(), key[:2], key[2:]+".html")
+}
+
+// highlightWithCache dispatches code to h, serving from and populating an
+// on-disk cache so repeated builds of unchanged code blocks skip the
+// backend (and, for the subprocess backends, the process spawn) entirely.
+func highlightWithCache(h Highlighter, backend, code, lexer, style, lineNumbers string) string {
+	cachePath := highlightCachePath(highlightCacheKey(backend, lexer, style, lineNumbers, code))
+
+	if cached, err := ioutil.ReadFile(cachePath); err == nil {
+		return string(cached)
+	}
+
+	out, err := h.Highlight(code, lexer, style, lineNumbers)
+	if err != nil {
+		jww.ERROR.Println(err)
+		return code
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0777); err != nil {
+		jww.WARN.Printf("could not create highlight cache dir: %s", err)
+		return out
+	}
+	if err := writeCacheFileAtomically(cachePath, []byte(out)); err != nil {
+		jww.WARN.Printf("could not write highlight cache entry: %s", err)
+	}
+
+	return out
+}
+
+// writeCacheFileAtomically writes data to a temp file in dst's directory and
+// renames it into place, so pages rendered concurrently never observe a
+// partially written cache entry.
+func writeCacheFileAtomically(dst string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(dst), filepath.Base(dst)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	return os.Rename(tmpName, dst)
+}
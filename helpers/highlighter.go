@@ -0,0 +1,139 @@
+// Copyright © 2013-14 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"os/exec"
+	"strings"
+
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+)
+
+const (
+	pygmentsBin  = "pygmentize"
+	highlightBin = "highlight"
+)
+
+// Highlighter renders a fragment of source code as syntax highlighted HTML.
+// Implementations are looked up by the Viper key HighlightBackend.
+type Highlighter interface {
+	Highlight(code, lexer, style, lineNumbers string) (string, error)
+}
+
+// PygmentsHighlighter shells out to the external "pygmentize" binary.
+type PygmentsHighlighter struct{}
+
+// Highlight implements Highlighter.
+func (p PygmentsHighlighter) Highlight(code, lexer, style, lineNumbers string) (string, error) {
+	if _, err := exec.LookPath(pygmentsBin); err != nil {
+		return "", fmt.Errorf("pygmentize not installed or not in path")
+	}
+
+	noclasses := "true"
+	if viper.GetBool("PygmentsUseClasses") {
+		noclasses = "false"
+	}
+
+	var out, stderr bytes.Buffer
+	cmd := exec.Command(pygmentsBin, "-l"+lexer, "-fhtml", "-O",
+		fmt.Sprintf("style=%s,noclasses=%s,encoding=utf8", style, noclasses))
+	cmd.Stdin = strings.NewReader(code)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("lexer: %v\ncode:\n%s\n%s", lexer, code, stderr.String())
+	}
+
+	return out.String(), nil
+}
+
+// HighlightHighlighter shells out to the external "highlight" binary. This
+// was the original, and remains the default, backend.
+type HighlightHighlighter struct{}
+
+// Highlight implements Highlighter.
+func (h HighlightHighlighter) Highlight(code, lexer, style, lineNumbers string) (string, error) {
+	if _, err := exec.LookPath(highlightBin); err != nil {
+		return "", fmt.Errorf("highlight not installed or not in path")
+	}
+
+	var out, stderr bytes.Buffer
+
+	lexerArg := "--syntax=" + lexer
+	styleArg := "--style=" + style
+	lineNumbersArg := ""
+	if lineNumbers == "y" || lineNumbers == "Y" {
+		lineNumbersArg = "-l"
+	}
+
+	cmd := exec.Command(highlightBin, "--enclose-pre", "-O xhtml", lineNumbersArg, "-K=14", "--fragment", "--include-style", "--inline-css", lexerArg, styleArg)
+	cmd.Stdin = strings.NewReader(code)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("lexer: %v\ncode:\n%s\n%s", lexer, code, stderr.String())
+	}
+
+	return out.String(), nil
+}
+
+// InternalHighlighter is a pure Go, in-process fallback that does no actual
+// colorization. It exists so sites can build without any external
+// highlighter installed, at the cost of plain (but still valid) markup.
+type InternalHighlighter struct{}
+
+// Highlight implements Highlighter.
+func (h InternalHighlighter) Highlight(code, lexer, style, lineNumbers string) (string, error) {
+	escaped := html.EscapeString(code)
+
+	if lineNumbers != "y" && lineNumbers != "Y" {
+		return fmt.Sprintf("<pre><code class=\"language-%s\">%s</code></pre>", lexer, escaped), nil
+	}
+
+	lines := strings.Split(escaped, "\n")
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("<pre><code class=\"language-%s\">", lexer))
+	for i, line := range lines {
+		buf.WriteString(fmt.Sprintf("<span class=\"ln\">%d</span>%s\n", i+1, line))
+	}
+	buf.WriteString("</code></pre>")
+
+	return buf.String(), nil
+}
+
+// highlighterBackends maps the Viper HighlightBackend key to its Highlighter.
+var highlighterBackends = map[string]Highlighter{
+	"pygments":  PygmentsHighlighter{},
+	"highlight": HighlightHighlighter{},
+	"internal":  InternalHighlighter{},
+}
+
+// getHighlighter returns the Highlighter registered for backend, falling
+// back to the HighlightHighlighter (the historical default) for an unknown
+// or empty backend name.
+func getHighlighter(backend string) Highlighter {
+	if h, ok := highlighterBackends[backend]; ok {
+		return h
+	}
+	if backend != "" {
+		jww.WARN.Printf("unknown HighlightBackend %q, falling back to %q", backend, "highlight")
+	}
+	return HighlightHighlighter{}
+}
@@ -18,67 +18,32 @@
 package helpers
 
 import (
-	"bytes"
 	"os/exec"
 	"strings"
 
-	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
 )
 
-const highlightBin = "highlight"
-
-// HasHighlight checks to see if highlight is installed and available
-// on the system.
+// HasHighlights checks to see if the "highlight" binary is installed and
+// available on the system.
 func HasHighlights() bool {
-	if _, err := exec.LookPath(highlightBin); err != nil {
-		return false
-	}
-	return true
+	_, err := exec.LookPath(highlightBin)
+	return err == nil
 }
 
-// Hilight takes some code and returns highlighted code.
+// Hilight takes some code and returns highlighted code. The actual work is
+// done by the Highlighter selected via the HighlightBackend Viper setting,
+// with results cached on disk so repeated builds of unchanged code blocks
+// don't pay the cost again.
 func Hilight(code string, lexer string, style string, lineNumbers string) string {
-
-	if !HasHighlights() {
-		jww.WARN.Println("Highlighting requires highlight to be installed and in the path")
-		return code
-	}
-
-	var out bytes.Buffer
-	var stderr bytes.Buffer
-
-	/*
-		style := viper.GetString("PygmentsStyle")
-
-		noclasses := "true"
-		if viper.GetBool("PygmentsUseClasses") {
-			noclasses = "false"
-		}
-
-		cmd := exec.Command(pygmentsBin, "-l"+lexer, "-fhtml", "-O",
-			fmt.Sprintf("style=%s,noclasses=%s,encoding=utf8", style, noclasses))
-	*/
 	// For some reason a blank line seems to be inserted into the code
 	// BEFORE this point, but it is unclear where. This cause an problem when
 	// you turn on line numbers because the initial blank line is counted.
 	// To avoicd this we shop all leading and training spaces
 	code = strings.TrimSpace(code)
-	lexer = "--syntax=" + lexer
-	style = "--style=" + style
-	if lineNumbers == "y" || lineNumbers == "Y" {
-		lineNumbers = "-l"
-	} else {
-		lineNumbers = ""
-	}
-	cmd := exec.Command(highlightBin, "--enclose-pre", "-O xhtml", lineNumbers, "-K=14", "--fragment", "--include-style", "--inline-css", lexer, style)
-	cmd.Stdin = strings.NewReader(code)
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
-		jww.ERROR.Printf("lexer: %v\ncode:\n%s\n%s", lexer, code, stderr.String())
-		return code
-	}
+	backend := viper.GetString("HighlightBackend")
+	h := getHighlighter(backend)
 
-	return out.String()
+	return highlightWithCache(h, backend, code, lexer, style, lineNumbers)
 }
@@ -32,8 +32,15 @@ var sanitizeRegexp = regexp.MustCompile("[^a-zA-Z0-9./_-]")
 // MakePath creates a Unicode sanitized string, with the spaces replaced, whilst
 // preserving the original casing of the string.
 // E.g. Social Media -> Social-Media
+// If the RemovePathAccents config flag is set, runes are first transliterated
+// to their closest ASCII equivalent (per the PathLanguage config, e.g. "de"),
+// so "Über Café" becomes "Ueber-Cafe" instead of keeping the raw "Über-Café".
 func MakePath(s string) string {
-	return UnicodeSanitize(strings.Replace(strings.TrimSpace(s), " ", "-", -1))
+	s = strings.Replace(strings.TrimSpace(s), " ", "-", -1)
+	if viper.GetBool("RemovePathAccents") {
+		s = Transliterate(s, viper.GetString("PathLanguage"))
+	}
+	return UnicodeSanitize(s)
 }
 
 // MakePathToLower creates a Unicode santized string, with the spaces replaced,
@@ -71,8 +78,8 @@ func ReplaceExtension(path string, newExt string) string {
 }
 
 // Check if Exists && is Directory
-func DirExists(path string) (bool, error) {
-	fi, err := os.Stat(path)
+func DirExists(fs Fs, path string) (bool, error) {
+	fi, err := fs.Stat(path)
 	if err == nil && fi.IsDir() {
 		return true, nil
 	}
@@ -82,32 +89,27 @@ func DirExists(path string) (bool, error) {
 	return false, err
 }
 
-func IsDir(path string) (bool, error) {
-	fi, err := os.Stat(path)
+func IsDir(fs Fs, path string) (bool, error) {
+	fi, err := fs.Stat(path)
 	if err != nil {
 		return false, err
 	}
 	return fi.IsDir(), nil
 }
 
-func IsEmpty(path string) (bool, error) {
-	if b, _ := Exists(path); !b {
+func IsEmpty(fs Fs, path string) (bool, error) {
+	if b, _ := Exists(fs, path); !b {
 		return false, fmt.Errorf("%q path does not exist", path)
 	}
-	fi, err := os.Stat(path)
+	fi, err := fs.Stat(path)
 	if err != nil {
 		return false, err
 	}
 	if fi.IsDir() {
-		f, err := os.Open(path)
-		// FIX: Resource leak - f.close() should be called here by defer or is missed
-		// if the err != nil branch is taken.
-		defer f.Close()
+		list, err := fs.ReadDir(path)
 		if err != nil {
 			return false, err
 		}
-		list, err := f.Readdir(-1)
-		// f.Close() - see bug fix above
 		return len(list) == 0, nil
 	} else {
 		return fi.Size() == 0, nil
@@ -115,8 +117,8 @@ func IsEmpty(path string) (bool, error) {
 }
 
 // Check if File / Directory Exists
-func Exists(path string) (bool, error) {
-	_, err := os.Stat(path)
+func Exists(fs Fs, path string) (bool, error) {
+	_, err := fs.Stat(path)
 	if err == nil {
 		return true, nil
 	}
@@ -126,6 +128,9 @@ func Exists(path string) (bool, error) {
 	return false, err
 }
 
+// AbsPathify does not take an Fs: it only joins and cleans path strings
+// against the configured WorkingDir and never touches the filesystem, so
+// there's nothing here for Fs to abstract.
 func AbsPathify(inPath string) string {
 	if filepath.IsAbs(inPath) {
 		return filepath.Clean(inPath)
@@ -239,18 +244,18 @@ func FindCWD() (string, error) {
 	return path, nil
 }
 
-func SafeWriteToDisk(inpath string, r io.Reader) (err error) {
+func SafeWriteToDisk(fs Fs, inpath string, r io.Reader) (err error) {
 	dir, _ := filepath.Split(inpath)
 	ospath := filepath.FromSlash(dir)
 
 	if ospath != "" {
-		err = os.MkdirAll(ospath, 0777) // rwx, rw, r
+		err = fs.MkdirAll(ospath, 0777) // rwx, rw, r
 		if err != nil {
 			return
 		}
 	}
 
-	exists, err := Exists(inpath)
+	exists, err := Exists(fs, inpath)
 	if err != nil {
 		return
 	}
@@ -258,7 +263,7 @@ func SafeWriteToDisk(inpath string, r io.Reader) (err error) {
 		return fmt.Errorf("%v already exists", inpath)
 	}
 
-	file, err := os.Create(inpath)
+	file, err := fs.Create(inpath)
 	if err != nil {
 		return
 	}
@@ -268,18 +273,18 @@ func SafeWriteToDisk(inpath string, r io.Reader) (err error) {
 	return
 }
 
-func WriteToDisk(inpath string, r io.Reader) (err error) {
+func WriteToDisk(fs Fs, inpath string, r io.Reader) (err error) {
 	dir, _ := filepath.Split(inpath)
 	ospath := filepath.FromSlash(dir)
 
 	if ospath != "" {
-		err = os.MkdirAll(ospath, 0777) // rwx, rw, r
+		err = fs.MkdirAll(ospath, 0777) // rwx, rw, r
 		if err != nil {
 			panic(err)
 		}
 	}
 
-	file, err := os.Create(inpath)
+	file, err := fs.Create(inpath)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,113 @@
+// Copyright © 2014 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import "strings"
+
+// translitBase maps individual runes to their closest ASCII equivalent. It
+// covers precomposed Latin-extended letters (including the tone-marked
+// vowels used by pinyin romanization), Cyrillic and Greek, and is applied
+// regardless of PathLanguage.
+var translitBase = map[rune]string{
+	// Latin-extended
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ǎ': "a", 'ą': "a",
+	'ç': "c", 'ć': "c", 'č': "c",
+	'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ĕ': "e", 'ě': "e", 'ę': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ĭ': "i", 'ǐ': "i",
+	'ł': "l",
+	'ñ': "n", 'ń': "n", 'ň': "n", 'ǹ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ŏ': "o", 'ǒ': "o",
+	'œ': "oe",
+	'ř': "r",
+	'š': "s", 'ś': "s", 'ş': "s",
+	'ß': "ss",
+	'ť': "t",
+	'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ŭ': "u", 'ǔ': "u", 'ů': "u", 'ǖ': "u", 'ǘ': "u", 'ǚ': "u", 'ǜ': "u",
+	'ý': "y", 'ÿ': "y",
+	'ž': "z", 'ź': "z", 'ż': "z",
+	'æ': "ae",
+
+	// Cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+
+	// Greek
+	'α': "a", 'β': "b", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i",
+	'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x",
+	'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y",
+	'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// translitLangOverrides holds per-PathLanguage exceptions to translitBase,
+// applied before it. Only "de" is special-cased for now; any other
+// PathLanguage value (including the default "generic") falls through to
+// translitBase.
+var translitLangOverrides = map[string]map[rune]string{
+	"de": {
+		'ä': "ae", 'ö': "oe", 'ü': "ue",
+		'Ä': "Ae", 'Ö': "Oe", 'Ü': "Ue",
+	},
+}
+
+func init() {
+	// Derive the uppercase rune entries (e.g. 'Ж' -> "Zh") from their
+	// lowercase counterparts instead of hand-duplicating the table above.
+	upper := make(map[rune]string, len(translitBase))
+	for r, repl := range translitBase {
+		if repl == "" {
+			// e.g. 'ъ'/'ь' drop out entirely; their uppercase forms should too.
+			continue
+		}
+		if u := []rune(strings.ToUpper(string(r))); len(u) == 1 && u[0] != r {
+			upper[u[0]] = strings.ToUpper(repl[:1]) + repl[1:]
+		}
+	}
+	for r, repl := range upper {
+		if _, exists := translitBase[r]; !exists {
+			translitBase[r] = repl
+		}
+	}
+}
+
+// Transliterate replaces runes in s that have a known ASCII equivalent,
+// honouring PathLanguage-specific overrides (currently just "de") before
+// falling back to the generic table. Runes with no mapping (e.g. CJK
+// ideographs) are passed through unchanged.
+func Transliterate(s string, lang string) string {
+	overrides := translitLangOverrides[lang]
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for _, r := range s {
+		if overrides != nil {
+			if repl, ok := overrides[r]; ok {
+				b.WriteString(repl)
+				continue
+			}
+		}
+		if repl, ok := translitBase[r]; ok {
+			b.WriteString(repl)
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,60 @@
+// Copyright © 2014 Steve Francia <spf@spf13.com>.
+//
+// Licensed under the Simple Public License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://opensource.org/licenses/Simple-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helpers
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		s    string
+		lang string
+		want string
+	}{
+		{"Über Café", "generic", "Uber Cafe"},
+		{"Über Café", "de", "Ueber Cafe"},
+		{"Жар", "generic", "Zhar"},
+		{"日本語", "generic", "日本語"},
+	}
+
+	for _, tt := range tests {
+		if got := Transliterate(tt.s, tt.lang); got != tt.want {
+			t.Errorf("Transliterate(%q, %q) = %q, want %q", tt.s, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestMakePathRemovePathAccents(t *testing.T) {
+	defer viper.Set("RemovePathAccents", viper.GetBool("RemovePathAccents"))
+	defer viper.Set("PathLanguage", viper.GetString("PathLanguage"))
+
+	viper.Set("RemovePathAccents", false)
+	if got, want := MakePath("Über Café"), "Über-Café"; got != want {
+		t.Errorf("MakePath with RemovePathAccents off = %q, want %q", got, want)
+	}
+
+	viper.Set("RemovePathAccents", true)
+	viper.Set("PathLanguage", "generic")
+	if got, want := MakePath("Über Café"), "Uber-Cafe"; got != want {
+		t.Errorf("MakePath with RemovePathAccents on (generic) = %q, want %q", got, want)
+	}
+
+	viper.Set("PathLanguage", "de")
+	if got, want := MakePath("Über Café"), "Ueber-Cafe"; got != want {
+		t.Errorf("MakePath with RemovePathAccents on (de) = %q, want %q", got, want)
+	}
+}